@@ -7,16 +7,16 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	ma "github.com/multiformats/go-multiaddr"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/multiformats/go-multibase"
 )
 
@@ -27,6 +27,7 @@ const sendRequestTimeout = 10 * time.Second
 
 // ProxyRequest represents the payload to send to the p2p-gateway.
 type ProxyRequest struct {
+	ID      string            `json:"id,omitempty"`
 	Method  string            `json:"method"`
 	Path    string            `json:"path"`
 	Headers map[string]string `json:"headers,omitempty"`
@@ -35,6 +36,7 @@ type ProxyRequest struct {
 
 // ProxyResponse is the response from p2p-gateway.
 type ProxyResponse struct {
+	ID      string            `json:"id,omitempty"`
 	Status  int               `json:"status"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Body    []byte            `json:"body,omitempty"`
@@ -44,39 +46,121 @@ type ProxyResponse struct {
 // Client hold the structures to sign messages and communicate with the p2p-gateway.
 type Client struct {
 	host           host.Host
-	privKey        ed25519.PrivateKey
+	signer         Signer
 	did            string
 	p2pGatewayAddr string
 	addrInfo       *peer.AddrInfo
+
+	// gateways holds every candidate gateway and its score. A single-gateway Client built
+	// via New/NewWithSigner still goes through a one-peer pool so request() has a single
+	// code path. See gateway.go.
+	gateways *gatewayPool
+
+	// events holds the lazily-initialized pubsub subscription state, guarded by eventsMu so
+	// concurrent first calls to SubscribeServiceEvents/WaitForAction don't each start their
+	// own subscription. See events.go.
+	eventsMu sync.Mutex
+	events   *eventState
+
+	// eventsCtx/eventsCancel own the lifetime of the pubsub subscription and its reader
+	// goroutine, independent of whatever ctx a particular SubscribeServiceEvents/
+	// WaitForAction caller passes in. A caller's (often short-lived) ctx must only bound
+	// that one call, not the shared subscription; eventsCtx is canceled exclusively by
+	// Close(). See events.go.
+	eventsCtx    context.Context
+	eventsCancel context.CancelFunc
+
+	// pooling config and state. See streampool.go.
+	maxStreams      int
+	idleTimeout     time.Duration
+	poolingDisabled bool
+	reqSeq          uint64
+	poolMu          sync.Mutex
+	pools           map[peer.ID]*streamPool
+
+	// codec encodes/decodes the ProxyRequest/ProxyResponse envelope. See codec.go.
+	codec Codec
+
+	// nonces generates nonces for the public methods that no longer take one explicitly.
+	// See nonce.go.
+	nonces NonceSource
+
+	// clockOffset is seconds to add to the local clock to match the gateway's, learned
+	// from gatewayTimestampHeader on a response. See clocksync.go.
+	clockOffset int64
 }
 
 // New creates a new client given a libp2p host which will be used to connect and send a message to the remote protocol.
 // in the params you can use any ed25519 private key to sign the messages. This should be the private key that the DID
 // was derived from and entered in serverista IAM DID Key.
 // proxyAddr
-func New(h host.Host, privKey ed25519.PrivateKey, p2pGatewayAddr string) (*Client, error) {
-	pubKey := privKey.Public().(ed25519.PublicKey)
-	did, err := Ed25519PubKeyToDID(pubKey)
+func New(h host.Host, privKey ed25519.PrivateKey, p2pGatewayAddr string, opts ...Option) (*Client, error) {
+	signer, err := NewEd25519KeySigner(privKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get DID from public key: %w", err)
+		return nil, err
 	}
 
-	maddr, err := ma.NewMultiaddr(p2pGatewayAddr)
+	return NewWithSigner(h, signer, p2pGatewayAddr, opts...)
+}
+
+// NewWithSigner creates a new client given a libp2p host and a Signer, which which will be used to connect and send
+// a message to the remote protocol. Use this instead of New to authenticate with a DID method other than the default
+// Ed25519 did:key, or to keep private key material out of process memory (e.g. an HSM/KMS-backed Signer).
+func NewWithSigner(h host.Host, signer Signer, p2pGatewayAddr string, opts ...Option) (*Client, error) {
+	return newClient(h, signer, []string{p2pGatewayAddr}, opts...)
+}
+
+// NewMulti creates a new client backed by several candidate gateways instead of one. If a
+// gateway goes down or starts timing out, request() fails over to the next best-scored
+// candidate; see Client.SetScoreParams and Client.GatewayStatus for tuning and
+// introspection.
+func NewMulti(h host.Host, privKey ed25519.PrivateKey, p2pGatewayAddrs []string, opts ...Option) (*Client, error) {
+	signer, err := NewEd25519KeySigner(privKey)
 	if err != nil {
-		return nil, fmt.Errorf("invalid peer multiaddr: %w", err)
+		return nil, err
 	}
-	info, err := peer.AddrInfoFromP2pAddr(maddr)
+
+	return NewMultiWithSigner(h, signer, p2pGatewayAddrs, opts...)
+}
+
+// NewMultiWithSigner is NewMulti for callers that need a Signer other than the default
+// Ed25519 did:key signer.
+func NewMultiWithSigner(h host.Host, signer Signer, p2pGatewayAddrs []string, opts ...Option) (*Client, error) {
+	return newClient(h, signer, p2pGatewayAddrs, opts...)
+}
+
+// newClient builds a Client backed by a gatewayPool over p2pGatewayAddrs. It is shared by
+// the single- and multi-gateway constructors so there is exactly one code path for
+// selecting and talking to a gateway.
+func newClient(h host.Host, signer Signer, p2pGatewayAddrs []string, opts ...Option) (*Client, error) {
+	pool, err := newGatewayPool(p2pGatewayAddrs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse peer addr info: %w", err)
+		return nil, err
 	}
+	primary := pool.candidates()[0]
+
+	eventsCtx, eventsCancel := context.WithCancel(context.Background())
 
-	return &Client{
+	c := &Client{
 		host:           h,
-		privKey:        privKey,
-		did:            did,
-		p2pGatewayAddr: p2pGatewayAddr,
-		addrInfo:       info,
-	}, nil
+		signer:         signer,
+		did:            signer.DID(),
+		p2pGatewayAddr: primary.addr,
+		addrInfo:       primary.addrInfo,
+		gateways:       pool,
+		maxStreams:     defaultMaxStreams,
+		idleTimeout:    defaultIdleTimeout,
+		codec:          JSONCodec{},
+		nonces:         NewRandomNonceSource(),
+		eventsCtx:      eventsCtx,
+		eventsCancel:   eventsCancel,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
 }
 
 // DID returns the DID for this client.
@@ -114,20 +198,23 @@ func buildPayload(method, path string, body []byte, nonce string, ts int64) stri
 func (c *Client) createCanonicalHeader(method, path string, body []byte, nonce string, ts int64) (string, []byte, error) {
 	payload := buildPayload(method, path, body, nonce, ts)
 
-	// sign payload
-	sig := ed25519.Sign(c.privKey, []byte(payload))
-	sigB64 := base64.StdEncoding.EncodeToString(sig)
+	sig, err := c.signer.Sign([]byte(payload))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign payload: %w", err)
+	}
 
-	// construct header
-	authHeader := fmt.Sprintf(
+	return c.signer.AuthHeader(sig, ts, nonce), sig, nil
+}
+
+// formatDIDAuthHeader formats the "DID ...;sig=...;ts=...;nonce=..." header shared by did:key-style signers.
+func formatDIDAuthHeader(did string, sig []byte, ts int64, nonce string) string {
+	return fmt.Sprintf(
 		"DID %s;sig=%s;ts=%d;nonce=%s",
-		c.did,
-		sigB64,
+		did,
+		base64.StdEncoding.EncodeToString(sig),
 		ts,
 		nonce,
 	)
-
-	return authHeader, sig, nil
 }
 
 // RawRequest sends a raw request given the method, path, body and other args
@@ -135,66 +222,184 @@ func (c *Client) RawRequest(ctx context.Context, method, path string, body []byt
 	return c.request(ctx, method, path, body, nonce, ts)
 }
 
-// request sends a raw request given all the required params.
+// request sends a raw request given all the required params. It tries every candidate
+// gateway best-scored first, recording each attempt's outcome against that gateway's
+// score so future calls prefer whichever gateway is currently fastest and healthiest; see
+// gateway.go. Unless the client was built with WithoutPooling, each attempt is multiplexed
+// onto a pooled stream to that gateway; otherwise it opens and closes a fresh stream.
 func (c *Client) request(ctx context.Context, method, path string, body []byte, nonce string, ts int64) (*ProxyResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, sendRequestTimeout)
 	defer cancel()
 
-	if err := c.host.Connect(ctx, *c.addrInfo); err != nil {
+	canonicalHeader, _, err := c.createCanonicalHeader(method, path, body, nonce, ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create canonical header: %w", err)
+	}
+
+	params := c.gateways.scoreParams()
+
+	var lastErr error
+	for _, gw := range c.gateways.candidates() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		req := ProxyRequest{
+			ID:     c.nextRequestID(),
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": canonicalHeader,
+			},
+			Body: body,
+		}
+
+		start := time.Now()
+		var resp *ProxyResponse
+		if c.poolingDisabled {
+			resp, err = c.requestOneShot(ctx, gw, req)
+		} else {
+			resp, err = c.requestPooled(ctx, gw, req)
+		}
+		if err != nil {
+			gw.recordFailure(err, params)
+			lastErr = err
+			continue
+		}
+
+		c.learnClockOffset(resp)
+
+		if resp.Error != "" {
+			err = errors.New(resp.Error)
+			gw.recordFailure(err, params)
+			lastErr = err
+			continue
+		}
+
+		gw.recordSuccess(time.Since(start), params)
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("all gateways failed: %w", lastErr)
+}
+
+// requestOneShot opens a fresh stream to gw, sends req, reads the matching response and
+// closes the stream. This is the original, non-pooled behavior.
+func (c *Client) requestOneShot(ctx context.Context, gw *gatewayPeer, req ProxyRequest) (*ProxyResponse, error) {
+	if err := c.host.Connect(ctx, *gw.addrInfo); err != nil {
 		return nil, fmt.Errorf("failed to connect to server: %w", err)
 	}
 
-	// open a new stream
-	s, err := c.host.NewStream(ctx, *&c.addrInfo.ID, ProtocolID)
+	s, err := c.host.NewStream(ctx, gw.addrInfo.ID, protocol.ID(protocolIDFor(c.codec)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open stream: %w", err)
 	}
 	defer s.Close()
 
-	// buffered writer/reader
 	br := bufio.NewReader(s)
 	bw := bufio.NewWriter(s)
 
-	canonicalHeader, _, err := c.createCanonicalHeader(method, path, body, nonce, ts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create canonical header: %w", err)
-	}
-
-	// prepare proxy request
-	req := ProxyRequest{
-		Method: method,
-		Path:   path,
-		Headers: map[string]string{
-			"Authorization": canonicalHeader,
-		},
-		Body: body,
-	}
-
-	// send request
-	if err := writeMessage(bw, req); err != nil {
+	if err := writeMessage(bw, c.codec, req); err != nil {
 		return nil, fmt.Errorf("failed to send request:: %w", err)
 	}
 	if err := bw.Flush(); err != nil {
 		return nil, fmt.Errorf("failed to flush: %w", err)
 	}
 
-	// read response
 	var resp ProxyResponse
-	if err := readMessage(br, &resp); err != nil {
+	if err := readMessage(br, c.codec, &resp); err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.Error != "" {
-		return nil, errors.New(resp.Error)
+	return &resp, nil
+}
+
+// requestPooled sends req on a stream pooled to gw and waits for the response carrying a
+// matching request ID.
+func (c *Client) requestPooled(ctx context.Context, gw *gatewayPeer, req ProxyRequest) (*ProxyResponse, error) {
+	pool := c.ensurePoolFor(gw)
+
+	ps, err := pool.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire pooled stream: %w", err)
 	}
 
-	return &resp, nil
+	ch, err := ps.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ensurePoolFor returns the stream pool dedicated to gw, lazily creating one on first use.
+func (c *Client) ensurePoolFor(gw *gatewayPeer) *streamPool {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	if c.pools == nil {
+		c.pools = make(map[peer.ID]*streamPool)
+	}
+	if p, ok := c.pools[gw.addrInfo.ID]; ok {
+		return p
+	}
+	p := newStreamPool(c.maxStreams, c.idleTimeout, func(ctx context.Context) (*pooledStream, error) {
+		return c.openPooledStreamTo(ctx, gw)
+	})
+	c.pools[gw.addrInfo.ID] = p
+	return p
+}
+
+// SetScoreParams tunes how this Client scores its candidate gateways. Safe to call at any
+// time; it takes effect on the next request.
+func (c *Client) SetScoreParams(params ScoreParams) {
+	c.gateways.setScoreParams(params)
+}
+
+// GatewayStatus reports the current score, last error and RTT stats for every candidate
+// gateway, so callers can surface gateway health in their own dashboards.
+func (c *Client) GatewayStatus() []GatewayStat {
+	return c.gateways.stats()
 }
 
-func (c *Client) Plans() {}
+// Close releases the Client's background resources: the gateway pool's score-decay loop,
+// any pooled streams opened by requestPooled, and the pubsub subscription backing
+// SubscribeServiceEvents/WaitForAction, if one was ever started. Safe to call even if those
+// were never used. The Client must not be used after Close.
+func (c *Client) Close() error {
+	c.eventsCancel()
+
+	c.gateways.close()
+
+	c.poolMu.Lock()
+	pools := make([]*streamPool, 0, len(c.pools))
+	for _, p := range c.pools {
+		pools = append(pools, p)
+	}
+	c.poolMu.Unlock()
+	for _, p := range pools {
+		p.close()
+	}
+
+	c.eventsMu.Lock()
+	es := c.events
+	c.eventsMu.Unlock()
+	if es != nil {
+		es.close()
+	}
+
+	return nil
+}
 
-func writeMessage(w io.Writer, v interface{}) error {
-	b, err := json.Marshal(v)
+// writeMessage encodes v with codec and writes it as a length-prefixed frame.
+func writeMessage(w io.Writer, codec Codec, v interface{}) error {
+	b, err := codec.Marshal(v)
 	if err != nil {
 		return err
 	}
@@ -207,7 +412,8 @@ func writeMessage(w io.Writer, v interface{}) error {
 	return err
 }
 
-func readMessage(r io.Reader, dst interface{}) error {
+// readMessage reads a length-prefixed frame and decodes it into dst with codec.
+func readMessage(r io.Reader, codec Codec, dst interface{}) error {
 	var lenbuf [4]byte
 	if _, err := io.ReadFull(r, lenbuf[:]); err != nil {
 		return err
@@ -220,5 +426,5 @@ func readMessage(r io.Reader, dst interface{}) error {
 	if _, err := io.ReadFull(r, data); err != nil {
 		return err
 	}
-	return json.Unmarshal(data, dst)
+	return codec.Unmarshal(data, dst)
 }
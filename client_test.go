@@ -3,6 +3,7 @@ package p2pclient
 import (
 	"bytes"
 	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"testing"
@@ -54,7 +55,11 @@ func TestCreateCanonicalHeaderAndSignature(t *testing.T) {
 		t.Fatalf("Ed25519PubKeyToDID error: %v", err)
 	}
 
-	c := &Client{privKey: priv, did: did}
+	signer, err := NewEd25519KeySigner(priv)
+	if err != nil {
+		t.Fatalf("NewEd25519KeySigner error: %v", err)
+	}
+	c := &Client{signer: signer, did: did}
 	method := "POST"
 	path := "/test/endpoint"
 	body := []byte("payload")
@@ -81,3 +86,65 @@ func TestCreateCanonicalHeaderAndSignature(t *testing.T) {
 		t.Fatalf("signature did not verify")
 	}
 }
+
+// mockSigner is a no-op Signer used to verify that createCanonicalHeader
+// defers header formatting to the Signer rather than assuming did:key.
+type mockSigner struct{}
+
+func (mockSigner) DID() string { return "did:mock:abc" }
+
+func (mockSigner) Sign(payload []byte) ([]byte, error) { return []byte("mock-sig"), nil }
+
+func (mockSigner) AuthHeader(sig []byte, ts int64, nonce string) string {
+	return fmt.Sprintf("MOCK %s;sig=%s;ts=%d;nonce=%s", "did:mock:abc", sig, ts, nonce)
+}
+
+func TestCreateCanonicalHeaderUsesSigner(t *testing.T) {
+	c := &Client{signer: mockSigner{}, did: "did:mock:abc"}
+
+	header, sig, err := c.createCanonicalHeader("GET", "/v1/plans", nil, "n-2", 1700000000)
+	if err != nil {
+		t.Fatalf("createCanonicalHeader error: %v", err)
+	}
+	if string(sig) != "mock-sig" {
+		t.Fatalf("expected mock signature, got %q", sig)
+	}
+	want := "MOCK did:mock:abc;sig=mock-sig;ts=1700000000;nonce=n-2"
+	if header != want {
+		t.Fatalf("header = %q, want %q", header, want)
+	}
+}
+
+func TestEd25519KeySignerMatchesCurrentWireFormat(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := NewEd25519KeySigner(priv)
+	if err != nil {
+		t.Fatalf("NewEd25519KeySigner error: %v", err)
+	}
+	c := &Client{signer: signer, did: signer.DID()}
+
+	method := "POST"
+	path := "/v1/services"
+	body := []byte(`{"plan_id":1}`)
+	nonce := "n-3"
+	ts := int64(1700000000)
+
+	header, sig, err := c.createCanonicalHeader(method, path, body, nonce, ts)
+	if err != nil {
+		t.Fatalf("createCanonicalHeader error: %v", err)
+	}
+
+	want := fmt.Sprintf(
+		"DID %s;sig=%s;ts=%d;nonce=%s",
+		signer.DID(),
+		base64.StdEncoding.EncodeToString(sig),
+		ts,
+		nonce,
+	)
+	if header != want {
+		t.Fatalf("header = %q, want %q (byte-for-byte wire format must match pre-refactor behavior)", header, want)
+	}
+}
@@ -0,0 +1,36 @@
+package p2pclient
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// gatewayTimestampHeader is the response header a gateway echoes its own unix timestamp
+// on, so a client with a skewed clock can learn the correction instead of having every
+// request rejected as stale.
+const gatewayTimestampHeader = "ts"
+
+// now returns the current unix timestamp adjusted by the clock offset learned from the
+// gateway, if any.
+func (c *Client) now() int64 {
+	return time.Now().Unix() + atomic.LoadInt64(&c.clockOffset)
+}
+
+// learnClockOffset updates the client's clock offset from a gateway-echoed timestamp. It
+// runs on every response, success or not, so a request rejected for a stale timestamp
+// still teaches the client the correction for its next attempt.
+func (c *Client) learnClockOffset(resp *ProxyResponse) {
+	if resp == nil {
+		return
+	}
+	raw, ok := resp.Headers[gatewayTimestampHeader]
+	if !ok {
+		return
+	}
+	gatewayTs, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return
+	}
+	atomic.StoreInt64(&c.clockOffset, gatewayTs-time.Now().Unix())
+}
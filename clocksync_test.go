@@ -0,0 +1,57 @@
+package p2pclient
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLearnClockOffsetAppliesGatewayTimestamp(t *testing.T) {
+	c := &Client{}
+
+	gatewayTs := time.Now().Add(1 * time.Hour).Unix()
+	c.learnClockOffset(&ProxyResponse{
+		Headers: map[string]string{gatewayTimestampHeader: strconv.FormatInt(gatewayTs, 10)},
+	})
+
+	if got := c.now(); got < gatewayTs-1 || got > gatewayTs+1 {
+		t.Fatalf("now() = %d, want close to gateway timestamp %d", got, gatewayTs)
+	}
+}
+
+func TestLearnClockOffsetIgnoresMissingOrMalformedHeader(t *testing.T) {
+	c := &Client{}
+	c.clockOffset = 42
+
+	c.learnClockOffset(nil)
+	if atomic.LoadInt64(&c.clockOffset) != 42 {
+		t.Fatalf("expected nil response to leave offset untouched")
+	}
+
+	c.learnClockOffset(&ProxyResponse{})
+	if atomic.LoadInt64(&c.clockOffset) != 42 {
+		t.Fatalf("expected response without the header to leave offset untouched")
+	}
+
+	c.learnClockOffset(&ProxyResponse{Headers: map[string]string{gatewayTimestampHeader: "not-a-number"}})
+	if atomic.LoadInt64(&c.clockOffset) != 42 {
+		t.Fatalf("expected a malformed header to leave offset untouched")
+	}
+}
+
+func TestLearnClockOffsetAppliesFromErrorResponseToo(t *testing.T) {
+	// A request rejected for a stale timestamp still carries the gateway's echoed clock, so
+	// the client should learn the correction even when resp.Error is set.
+	c := &Client{}
+
+	gatewayTs := time.Now().Add(-30 * time.Minute).Unix()
+	c.learnClockOffset(&ProxyResponse{
+		Error:   "timestamp too old",
+		Headers: map[string]string{gatewayTimestampHeader: strconv.FormatInt(gatewayTs, 10)},
+	})
+
+	if got := c.now(); got < gatewayTs-1 || got > gatewayTs+1 {
+		t.Fatalf("now() = %d, want close to gateway timestamp %d", got, gatewayTs)
+	}
+}
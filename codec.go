@@ -0,0 +1,59 @@
+package p2pclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Codec encodes and decodes the ProxyRequest/ProxyResponse envelope sent over the wire.
+// The default is JSONCodec; CBORCodec trades human-readability for smaller, cheaper to
+// decode payloads on large CreateServiceRequest bodies and ListServices responses.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// ContentType identifies the codec on the wire, e.g. for picking a protocol ID.
+	ContentType() string
+}
+
+// JSONCodec encodes the envelope as JSON. This is the default and matches the client's
+// original, pre-codec-negotiation wire format byte-for-byte.
+type JSONCodec struct{}
+
+// ContentType returns "json".
+func (JSONCodec) ContentType() string { return "json" }
+
+// Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes JSON into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// CBORCodec encodes the envelope as CBOR, advertised under ProtocolID+"+cbor".
+type CBORCodec struct{}
+
+// ContentType returns "cbor".
+func (CBORCodec) ContentType() string { return "cbor" }
+
+// Marshal encodes v as CBOR.
+func (CBORCodec) Marshal(v interface{}) ([]byte, error) { return cbor.Marshal(v) }
+
+// Unmarshal decodes CBOR into v.
+func (CBORCodec) Unmarshal(data []byte, v interface{}) error { return cbor.Unmarshal(data, v) }
+
+// WithCodec selects the Codec used to encode the ProxyRequest/ProxyResponse envelope and
+// the protocol ID advertised when opening streams. Defaults to JSONCodec.
+func WithCodec(codec Codec) Option {
+	return func(c *Client) { c.codec = codec }
+}
+
+// protocolIDFor returns the libp2p protocol ID to advertise for codec. Non-JSON codecs get
+// a suffixed protocol ID. Streams are opened with this one protocol ID only, so a gateway
+// that doesn't understand the codec refuses the stream; there is no fallback to plain JSON.
+func protocolIDFor(codec Codec) string {
+	if codec == nil || codec.ContentType() == (JSONCodec{}).ContentType() {
+		return ProtocolID
+	}
+	return fmt.Sprintf("%s+%s", ProtocolID, codec.ContentType())
+}
@@ -0,0 +1,164 @@
+package p2pclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// newMatrixClient builds a Client wired to an in-process fake gateway over a net.Pipe,
+// entirely bypassing libp2p host dialing (the pool's only stream is pre-seeded, so
+// ensurePoolFor/acquire never call openFn), so the public API methods can be exercised
+// against a real handler under every Codec.
+func newMatrixClient(t *testing.T, codec Codec, handler func(ProxyRequest) ProxyResponse) *Client {
+	t.Helper()
+
+	pool := newTestGatewayPool(t, 1)
+	gw := pool.peers[0]
+
+	clientConn, gatewayConn := net.Pipe()
+	ps := &pooledStream{
+		s:        &fakeStream{conn: clientConn},
+		bw:       bufio.NewWriter(clientConn),
+		codec:    codec,
+		pending:  make(map[string]chan *ProxyResponse),
+		closed:   make(chan struct{}),
+		lastUsed: time.Now(),
+	}
+	go ps.readLoop()
+
+	go func() {
+		br := bufio.NewReader(gatewayConn)
+		bw := bufio.NewWriter(gatewayConn)
+		for {
+			var req ProxyRequest
+			if err := readMessage(br, codec, &req); err != nil {
+				return
+			}
+			resp := handler(req)
+			resp.ID = req.ID
+			if err := writeMessage(bw, codec, resp); err != nil {
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sp := newStreamPool(1, time.Minute, func(ctx context.Context) (*pooledStream, error) {
+		return nil, fmt.Errorf("unexpected dial: matrix test pre-seeds its stream")
+	})
+	sp.streams = []*pooledStream{ps}
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := NewEd25519KeySigner(priv)
+	if err != nil {
+		t.Fatalf("NewEd25519KeySigner: %v", err)
+	}
+
+	return &Client{
+		signer:   signer,
+		did:      signer.DID(),
+		addrInfo: gw.addrInfo,
+		gateways: pool,
+		codec:    codec,
+		nonces:   NewRandomNonceSource(),
+		pools:    map[peer.ID]*streamPool{gw.addrInfo.ID: sp},
+	}
+}
+
+// TestAPIMethodsRoundTripAcrossCodecs exercises every public API method against an
+// in-process fake gateway under both JSONCodec and CBORCodec, so a codec that only
+// changes the envelope protocol ID while leaving the body always JSON-encoded (the bug
+// that shipped alongside WithCodec) would fail this under CBOR.
+func TestAPIMethodsRoundTripAcrossCodecs(t *testing.T) {
+	codecs := []Codec{JSONCodec{}, CBORCodec{}}
+
+	for _, codec := range codecs {
+		codec := codec
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			wantService := Service{ID: 1, AccountID: 2, UserID: 3, PlanID: 4, Status: "running", UserDefinedName: "box", IP: "10.0.0.1"}
+			wantPlan := Plan{ID: 1, Name: "starter", Type: VPSPlan, Price: 9.99}
+
+			handler := func(req ProxyRequest) ProxyResponse {
+				switch {
+				case req.Method == CreateServicesEndpoint.Method && req.Path == CreateServicesEndpoint.Uri:
+					var created CreateServiceRequest
+					if err := codec.Unmarshal(req.Body, &created); err != nil {
+						return ProxyResponse{Status: 400, Error: err.Error()}
+					}
+					body, _ := codec.Marshal([]Service{wantService})
+					return ProxyResponse{Status: 200, Body: body}
+				case req.Method == ListUserServicesEndpoint.Method && req.Path == ListUserServicesEndpoint.Uri:
+					body, _ := codec.Marshal([]Service{wantService})
+					return ProxyResponse{Status: 200, Body: body}
+				case req.Method == GetUserServiceEndpoint.Method && req.Path == fmt.Sprintf(GetUserServiceEndpoint.Uri, wantService.ID):
+					body, _ := codec.Marshal(wantService)
+					return ProxyResponse{Status: 200, Body: body}
+				case req.Method == ManageServiceEndpoint.Method && req.Path == fmt.Sprintf(ManageServiceEndpoint.Uri, wantService.ID):
+					var action serviceActionRequest
+					if err := codec.Unmarshal(req.Body, &action); err != nil {
+						return ProxyResponse{Status: 400, Error: err.Error()}
+					}
+					body, _ := codec.Marshal(serviceActionResponse{ActionID: "act-1"})
+					return ProxyResponse{Status: 200, Body: body}
+				case req.Method == PlansEndpoint.Method && req.Path == PlansEndpoint.Uri:
+					body, _ := codec.Marshal([]Plan{wantPlan})
+					return ProxyResponse{Status: 200, Body: body}
+				default:
+					return ProxyResponse{Status: 404, Error: fmt.Sprintf("unhandled %s %s", req.Method, req.Path)}
+				}
+			}
+
+			c := newMatrixClient(t, codec, handler)
+
+			services, err := c.CreateServices(context.Background(), CreateServiceRequest{
+				PlanID: 1, OS: Ubuntu24_04, Amount: 1, SSHPublicKey: "ssh-ed25519 AAAA",
+			})
+			if err != nil {
+				t.Fatalf("CreateServices: %v", err)
+			}
+			if len(services) != 1 || services[0].ID != wantService.ID {
+				t.Fatalf("CreateServices = %+v, want one service with ID %d", services, wantService.ID)
+			}
+
+			services, err = c.ListServices(context.Background())
+			if err != nil {
+				t.Fatalf("ListServices: %v", err)
+			}
+			if len(services) != 1 || services[0].IP != wantService.IP {
+				t.Fatalf("ListServices = %+v, want IP %q", services, wantService.IP)
+			}
+
+			service, err := c.GetService(context.Background(), wantService.ID)
+			if err != nil {
+				t.Fatalf("GetService: %v", err)
+			}
+			if service.UserDefinedName != wantService.UserDefinedName {
+				t.Fatalf("GetService = %+v, want UserDefinedName %q", service, wantService.UserDefinedName)
+			}
+
+			if err := c.ServiceAction(context.Background(), ServiceRestart, wantService.ID); err != nil {
+				t.Fatalf("ServiceAction: %v", err)
+			}
+
+			plans, err := c.Plans(context.Background())
+			if err != nil {
+				t.Fatalf("Plans: %v", err)
+			}
+			if len(plans) != 1 || plans[0].Name != wantPlan.Name {
+				t.Fatalf("Plans = %+v, want one plan named %q", plans, wantPlan.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package p2pclient
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteReadMessageRoundTripAcrossCodecs(t *testing.T) {
+	codecs := []Codec{JSONCodec{}, CBORCodec{}}
+
+	for _, codec := range codecs {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			req := ProxyRequest{
+				ID:     "req-1",
+				Method: "POST",
+				Path:   "/v1/services",
+				Headers: map[string]string{
+					"Authorization": "DID did:key:z123;sig=abc;ts=1700000000;nonce=n-1",
+				},
+				Body: []byte(`{"plan_id":1}`),
+			}
+
+			var buf bytes.Buffer
+			if err := writeMessage(&buf, codec, req); err != nil {
+				t.Fatalf("writeMessage: %v", err)
+			}
+
+			var got ProxyRequest
+			if err := readMessage(&buf, codec, &got); err != nil {
+				t.Fatalf("readMessage: %v", err)
+			}
+
+			if got.ID != req.ID || got.Method != req.Method || got.Path != req.Path {
+				t.Fatalf("round-tripped request = %+v, want %+v", got, req)
+			}
+			if !bytes.Equal(got.Body, req.Body) {
+				t.Fatalf("round-tripped body = %q, want %q", got.Body, req.Body)
+			}
+			if got.Headers["Authorization"] != req.Headers["Authorization"] {
+				t.Fatalf("round-tripped headers = %+v, want %+v", got.Headers, req.Headers)
+			}
+		})
+	}
+}
+
+func TestProtocolIDForCodec(t *testing.T) {
+	if got := protocolIDFor(JSONCodec{}); got != ProtocolID {
+		t.Fatalf("protocolIDFor(JSONCodec{}) = %q, want %q", got, ProtocolID)
+	}
+	if got := protocolIDFor(nil); got != ProtocolID {
+		t.Fatalf("protocolIDFor(nil) = %q, want %q", got, ProtocolID)
+	}
+
+	want := ProtocolID + "+cbor"
+	if got := protocolIDFor(CBORCodec{}); got != want {
+		t.Fatalf("protocolIDFor(CBORCodec{}) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package p2pclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ServiceEventsProtocolID is the gossipsub topic/protocol prefix used to stream async
+// ServiceAction progress from the gateway.
+const ServiceEventsProtocolID = "/serverista-proxy-events/1.0.0"
+
+// maxRecentEvents bounds the ring buffer of recently observed events, so a WaitForAction
+// call that starts just after a matching event was published can still find it.
+const maxRecentEvents = 64
+
+// ServiceEvent reports the status of an in-flight or completed service action.
+type ServiceEvent struct {
+	ActionID  string `json:"action_id"`
+	ServiceID uint   `json:"service_id"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+	Error     string `json:"error,omitempty"`
+}
+
+// terminalEventStatuses are the ServiceEvent.Status values that settle a service action;
+// any other status (e.g. "RUNNING") is an in-progress update that WaitForAction must keep
+// waiting past.
+var terminalEventStatuses = map[string]struct{}{
+	"COMPLETE": {},
+	"FAILED":   {},
+}
+
+func isTerminalStatus(status string) bool {
+	_, ok := terminalEventStatuses[status]
+	return ok
+}
+
+// signedServiceEvent is the wire envelope published on the events topic. Nonce and
+// Timestamp feed the same canonical payload scheme used for requests, so a gateway
+// peer can't replay an old event as if it were new.
+type signedServiceEvent struct {
+	Event ServiceEvent `json:"event"`
+	Nonce string       `json:"nonce"`
+	Sig   []byte       `json:"sig"`
+}
+
+// eventState holds the client's lazily-initialized pubsub subscription.
+type eventState struct {
+	ps    *pubsub.PubSub
+	topic *pubsub.Topic
+	sub   *pubsub.Subscription
+	// closeOnce guards against canceling the subscription/topic twice.
+	closeOnce sync.Once
+
+	out chan ServiceEvent
+
+	waitersMu sync.Mutex
+	waiters   map[string][]chan ServiceEvent
+
+	recentMu   sync.Mutex
+	recent     []ServiceEvent
+	seenNonces map[string]struct{}
+}
+
+// SubscribeServiceEvents joins the per-account events topic and returns a channel of
+// ServiceEvent updates streamed by the gateway. The channel is shared by every subscriber
+// and is only closed by Close(); ctx is not used to bound its lifetime.
+func (c *Client) SubscribeServiceEvents(ctx context.Context) (<-chan ServiceEvent, error) {
+	es, err := c.ensureEventState()
+	if err != nil {
+		return nil, err
+	}
+	return es.out, nil
+}
+
+// WaitForAction blocks until a ServiceEvent matching actionID with a terminal status is
+// observed on the events topic, or ctx is done. It replays from the recent-events ring
+// buffer first, so an action that already completed before WaitForAction was called is
+// still found.
+func (c *Client) WaitForAction(ctx context.Context, actionID string) (*ServiceEvent, error) {
+	es, err := c.ensureEventState()
+	if err != nil {
+		return nil, err
+	}
+
+	if ev := es.findRecent(actionID); ev != nil {
+		return ev, nil
+	}
+
+	ch := make(chan ServiceEvent, 1)
+	es.addWaiter(actionID, ch)
+	defer es.removeWaiter(actionID, ch)
+
+	// the event may have landed between the recent-buffer check and addWaiter.
+	if ev := es.findRecent(actionID); ev != nil {
+		return ev, nil
+	}
+
+	select {
+	case ev := <-ch:
+		return &ev, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// eventsTopicName derives the gossipsub topic for this account's events from its DID,
+// so only the account owner and the gateway need to agree on it out of band.
+func eventsTopicName(did string) string {
+	sum := sha256.Sum256([]byte(did))
+	return ServiceEventsProtocolID + "/" + hex.EncodeToString(sum[:])
+}
+
+// ensureEventState lazily starts the shared pubsub subscription the first time any caller
+// asks for events. Its lifetime is bound to c.eventsCtx rather than this particular
+// caller's ctx: a short-lived ctx (e.g. one from a WithTimeout wrapped around a single
+// WaitForAction call) must not tear down a subscription every other caller still expects
+// to be alive. The subscription is only ever torn down by Close().
+func (c *Client) ensureEventState() (*eventState, error) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	if c.events != nil {
+		return c.events, nil
+	}
+
+	ps, err := pubsub.NewGossipSub(c.eventsCtx, c.host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gossipsub: %w", err)
+	}
+
+	topic, err := ps.Join(eventsTopicName(c.did))
+	if err != nil {
+		return nil, fmt.Errorf("failed to join events topic: %w", err)
+	}
+
+	sub, err := topic.Subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to events topic: %w", err)
+	}
+
+	es := &eventState{
+		ps:         ps,
+		topic:      topic,
+		sub:        sub,
+		out:        make(chan ServiceEvent, 16),
+		waiters:    make(map[string][]chan ServiceEvent),
+		seenNonces: make(map[string]struct{}),
+	}
+	c.events = es
+
+	go c.readEvents(c.eventsCtx, es)
+
+	return es, nil
+}
+
+func (c *Client) readEvents(ctx context.Context, es *eventState) {
+	defer close(es.out)
+
+	for {
+		msg, err := es.sub.Next(ctx)
+		if err != nil {
+			return
+		}
+
+		// only a peer from this client's gateway pool may publish events on this topic.
+		// Checking pool membership rather than a single addrInfo captured at construction
+		// means a published event is still trusted after request() has failed over to a
+		// different (better-scored) candidate gateway.
+		if !c.gateways.contains(msg.ReceivedFrom) {
+			continue
+		}
+
+		var signed signedServiceEvent
+		if err := json.Unmarshal(msg.Data, &signed); err != nil {
+			continue
+		}
+
+		payload := buildPayload("EVENT", signed.Event.ActionID, mustMarshal(signed.Event), signed.Nonce, signed.Event.Timestamp)
+		if !c.verifyGatewaySignature(ctx, msg.ReceivedFrom, []byte(payload), signed.Sig) {
+			continue
+		}
+
+		if !es.markNonceSeen(signed.Nonce) {
+			// already processed this event; drop the replay.
+			continue
+		}
+
+		es.recordRecent(signed.Event)
+		es.dispatch(signed.Event)
+
+		select {
+		case es.out <- signed.Event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// verifyGatewaySignature checks payload against from's libp2p public key.
+func (c *Client) verifyGatewaySignature(ctx context.Context, from peer.ID, payload, sig []byte) bool {
+	pubKey := c.host.Peerstore().PubKey(from)
+	if pubKey == nil {
+		pk, err := from.ExtractPublicKey()
+		if err != nil {
+			return false
+		}
+		pubKey = pk
+	}
+
+	ok, err := pubKey.Verify(payload, sig)
+	return err == nil && ok
+}
+
+func (es *eventState) markNonceSeen(nonce string) bool {
+	es.recentMu.Lock()
+	defer es.recentMu.Unlock()
+	if _, seen := es.seenNonces[nonce]; seen {
+		return false
+	}
+	es.seenNonces[nonce] = struct{}{}
+	return true
+}
+
+func (es *eventState) recordRecent(ev ServiceEvent) {
+	es.recentMu.Lock()
+	defer es.recentMu.Unlock()
+	es.recent = append(es.recent, ev)
+	if len(es.recent) > maxRecentEvents {
+		es.recent = es.recent[len(es.recent)-maxRecentEvents:]
+	}
+}
+
+// findRecent looks for a terminal-status event matching actionID, ignoring intermediate
+// progress events (e.g. "RUNNING") so a caller never gets woken before the action settles.
+func (es *eventState) findRecent(actionID string) *ServiceEvent {
+	es.recentMu.Lock()
+	defer es.recentMu.Unlock()
+	for i := len(es.recent) - 1; i >= 0; i-- {
+		if es.recent[i].ActionID == actionID && isTerminalStatus(es.recent[i].Status) {
+			ev := es.recent[i]
+			return &ev
+		}
+	}
+	return nil
+}
+
+func (es *eventState) addWaiter(actionID string, ch chan ServiceEvent) {
+	es.waitersMu.Lock()
+	defer es.waitersMu.Unlock()
+	es.waiters[actionID] = append(es.waiters[actionID], ch)
+}
+
+func (es *eventState) removeWaiter(actionID string, ch chan ServiceEvent) {
+	es.waitersMu.Lock()
+	defer es.waitersMu.Unlock()
+	waiters := es.waiters[actionID]
+	for i, w := range waiters {
+		if w == ch {
+			es.waiters[actionID] = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+}
+
+// dispatch wakes any WaitForAction callers blocked on ev.ActionID, but only once ev has
+// reached a terminal status; intermediate progress events are recorded (recordRecent) but
+// must not satisfy a waiter.
+func (es *eventState) dispatch(ev ServiceEvent) {
+	if !isTerminalStatus(ev.Status) {
+		return
+	}
+
+	es.waitersMu.Lock()
+	defer es.waitersMu.Unlock()
+	for _, ch := range es.waiters[ev.ActionID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// close tears down the pubsub subscription and topic, which causes readEvents' blocking
+// sub.Next to return an error and exit. Safe to call more than once.
+func (es *eventState) close() {
+	es.closeOnce.Do(func() {
+		if es.sub != nil {
+			es.sub.Cancel()
+		}
+		if es.topic != nil {
+			es.topic.Close()
+		}
+	})
+}
+
+func mustMarshal(ev ServiceEvent) []byte {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		// ServiceEvent only has marshalable fields; this should never happen.
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,109 @@
+package p2pclient
+
+import (
+	"testing"
+)
+
+func TestEventStateReplayProtection(t *testing.T) {
+	es := &eventState{seenNonces: make(map[string]struct{})}
+
+	if !es.markNonceSeen("n-1") {
+		t.Fatalf("expected first sighting of nonce to be accepted")
+	}
+	if es.markNonceSeen("n-1") {
+		t.Fatalf("expected replayed nonce to be rejected")
+	}
+	if !es.markNonceSeen("n-2") {
+		t.Fatalf("expected a distinct nonce to be accepted")
+	}
+}
+
+func TestEventStateFindRecent(t *testing.T) {
+	es := &eventState{}
+
+	if es.findRecent("missing") != nil {
+		t.Fatalf("expected no match on empty ring buffer")
+	}
+
+	es.recordRecent(ServiceEvent{ActionID: "a1", Status: "RUNNING"})
+	es.recordRecent(ServiceEvent{ActionID: "a2", Status: "COMPLETE"})
+
+	ev := es.findRecent("a2")
+	if ev == nil || ev.Status != "COMPLETE" {
+		t.Fatalf("expected to find a2 with status COMPLETE, got %+v", ev)
+	}
+
+	for i := 0; i < maxRecentEvents+5; i++ {
+		es.recordRecent(ServiceEvent{ActionID: "filler"})
+	}
+	if es.findRecent("a2") != nil {
+		t.Fatalf("expected a2 to have been evicted from the bounded ring buffer")
+	}
+}
+
+func TestEventStateDispatchDeliversToWaiter(t *testing.T) {
+	es := &eventState{waiters: make(map[string][]chan ServiceEvent)}
+
+	ch := make(chan ServiceEvent, 1)
+	es.addWaiter("a1", ch)
+
+	es.dispatch(ServiceEvent{ActionID: "a1", Status: "COMPLETE"})
+
+	select {
+	case ev := <-ch:
+		if ev.Status != "COMPLETE" {
+			t.Fatalf("expected COMPLETE status, got %q", ev.Status)
+		}
+	default:
+		t.Fatalf("expected waiter to receive dispatched event")
+	}
+
+	es.removeWaiter("a1", ch)
+	if len(es.waiters["a1"]) != 0 {
+		t.Fatalf("expected waiter to be removed")
+	}
+}
+
+func TestEventStateDispatchIgnoresNonTerminalStatus(t *testing.T) {
+	es := &eventState{waiters: make(map[string][]chan ServiceEvent)}
+
+	ch := make(chan ServiceEvent, 1)
+	es.addWaiter("a1", ch)
+
+	es.dispatch(ServiceEvent{ActionID: "a1", Status: "RUNNING"})
+
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected a non-terminal event not to wake the waiter, got %+v", ev)
+	default:
+	}
+}
+
+func TestEventStateFindRecentIgnoresNonTerminalStatus(t *testing.T) {
+	es := &eventState{}
+
+	es.recordRecent(ServiceEvent{ActionID: "a1", Status: "RUNNING"})
+
+	if ev := es.findRecent("a1"); ev != nil {
+		t.Fatalf("expected a RUNNING event not to satisfy findRecent, got %+v", ev)
+	}
+
+	es.recordRecent(ServiceEvent{ActionID: "a1", Status: "COMPLETE"})
+
+	ev := es.findRecent("a1")
+	if ev == nil || ev.Status != "COMPLETE" {
+		t.Fatalf("expected to find the terminal COMPLETE event, got %+v", ev)
+	}
+}
+
+func TestEventsTopicNameIsStableAndScopedToDID(t *testing.T) {
+	a := eventsTopicName("did:key:zAlice")
+	b := eventsTopicName("did:key:zBob")
+
+	if a == b {
+		t.Fatalf("expected different DIDs to derive different topics")
+	}
+	if eventsTopicName("did:key:zAlice") != a {
+		t.Fatalf("expected topic derivation to be deterministic")
+	}
+}
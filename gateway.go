@@ -0,0 +1,267 @@
+package p2pclient
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ScoreParams tunes how gateway peer scores are computed and decayed. Pass to
+// Client.SetScoreParams; a Client started with NewMulti/NewMultiWithSigner uses
+// DefaultScoreParams until SetScoreParams is called.
+type ScoreParams struct {
+	// DecayInterval is how often every gateway's score is halved back toward zero, so a
+	// gateway that failed in the past doesn't stay penalized forever once it recovers.
+	DecayInterval time.Duration
+	// LatencyWeight scales how much a successful request's round-trip time (in seconds)
+	// counts against its score; higher weight favors faster gateways more strongly.
+	LatencyWeight float64
+	// FailurePenalty is subtracted from a gateway's score on a timeout, auth rejection, or
+	// stream-open failure.
+	FailurePenalty float64
+	// MinAcceptableScore is the floor below which a gateway is considered unhealthy and is
+	// only tried after every healthy candidate has been tried.
+	MinAcceptableScore float64
+}
+
+// DefaultScoreParams returns the scoring tunables a Client starts with.
+func DefaultScoreParams() ScoreParams {
+	return ScoreParams{
+		DecayInterval:      30 * time.Second,
+		LatencyWeight:      1,
+		FailurePenalty:     10,
+		MinAcceptableScore: -20,
+	}
+}
+
+// GatewayStat reports the current health of one candidate gateway, for callers that want
+// to surface gateway status in their own dashboards. See Client.GatewayStatus.
+type GatewayStat struct {
+	PeerID   peer.ID
+	Addr     string
+	Score    float64
+	LastErr  error
+	MinRTT   time.Duration
+	MaxRTT   time.Duration
+	AvgRTT   time.Duration
+	Requests int
+}
+
+// gatewayPeer tracks the scored health of a single candidate gateway, inspired by the peer
+// scoring used by gossipsub/BlossomSub: requests that succeed quickly raise the score,
+// timeouts/auth rejections/stream-open failures lower it.
+type gatewayPeer struct {
+	addrInfo *peer.AddrInfo
+	addr     string
+
+	mu       sync.Mutex
+	score    float64
+	lastErr  error
+	minRTT   time.Duration
+	maxRTT   time.Duration
+	sumRTT   time.Duration
+	requests int
+}
+
+func newGatewayPeer(addr string) (*gatewayPeer, error) {
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer multiaddr: %w", err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse peer addr info: %w", err)
+	}
+	return &gatewayPeer{addrInfo: info, addr: addr}, nil
+}
+
+func (g *gatewayPeer) recordSuccess(rtt time.Duration, params ScoreParams) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastErr = nil
+	g.requests++
+	if g.requests == 1 || rtt < g.minRTT {
+		g.minRTT = rtt
+	}
+	if rtt > g.maxRTT {
+		g.maxRTT = rtt
+	}
+	g.sumRTT += rtt
+	g.score += 1 - params.LatencyWeight*rtt.Seconds()
+}
+
+func (g *gatewayPeer) recordFailure(err error, params ScoreParams) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastErr = err
+	g.score -= params.FailurePenalty
+}
+
+func (g *gatewayPeer) decay() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.score *= 0.5
+}
+
+func (g *gatewayPeer) snapshot() (score float64, lastErr error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.score, g.lastErr
+}
+
+func (g *gatewayPeer) stat() GatewayStat {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stat := GatewayStat{
+		PeerID:   g.addrInfo.ID,
+		Addr:     g.addr,
+		Score:    g.score,
+		LastErr:  g.lastErr,
+		MinRTT:   g.minRTT,
+		MaxRTT:   g.maxRTT,
+		Requests: g.requests,
+	}
+	if g.requests > 0 {
+		stat.AvgRTT = g.sumRTT / time.Duration(g.requests)
+	}
+	return stat
+}
+
+// gatewayPool holds the scored candidate gateways for a Client and orders them best-first
+// for each request, so request() can fail over from one gateway to the next.
+type gatewayPool struct {
+	mu     sync.Mutex
+	params ScoreParams
+	peers  []*gatewayPeer
+
+	stopOnce  sync.Once
+	stopDecay chan struct{}
+}
+
+func newGatewayPool(addrs []string) (*gatewayPool, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("at least one gateway address is required")
+	}
+
+	peers := make([]*gatewayPeer, 0, len(addrs))
+	for _, addr := range addrs {
+		gp, err := newGatewayPeer(addr)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, gp)
+	}
+
+	pool := &gatewayPool{
+		params:    DefaultScoreParams(),
+		peers:     peers,
+		stopDecay: make(chan struct{}),
+	}
+	go pool.decayLoop()
+	return pool, nil
+}
+
+func (p *gatewayPool) decayLoop() {
+	for {
+		p.mu.Lock()
+		interval := p.params.DecayInterval
+		p.mu.Unlock()
+		if interval <= 0 {
+			interval = time.Minute
+		}
+
+		select {
+		case <-time.After(interval):
+			p.mu.Lock()
+			peers := append([]*gatewayPeer(nil), p.peers...)
+			p.mu.Unlock()
+			for _, gp := range peers {
+				gp.decay()
+			}
+		case <-p.stopDecay:
+			return
+		}
+	}
+}
+
+func (p *gatewayPool) close() {
+	p.stopOnce.Do(func() { close(p.stopDecay) })
+}
+
+func (p *gatewayPool) setScoreParams(params ScoreParams) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.params = params
+}
+
+func (p *gatewayPool) scoreParams() ScoreParams {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.params
+}
+
+// candidates returns every gateway peer ordered best-first: healthy peers (score at or
+// above MinAcceptableScore) by descending score, then unhealthy ones by descending score
+// as a last resort, so a request still has somewhere to go if every gateway is currently
+// unhealthy.
+func (p *gatewayPool) candidates() []*gatewayPeer {
+	p.mu.Lock()
+	params := p.params
+	peers := append([]*gatewayPeer(nil), p.peers...)
+	p.mu.Unlock()
+
+	type scored struct {
+		peer    *gatewayPeer
+		score   float64
+		healthy bool
+	}
+	snapshot := make([]scored, len(peers))
+	for i, gp := range peers {
+		score, _ := gp.snapshot()
+		snapshot[i] = scored{peer: gp, score: score, healthy: score >= params.MinAcceptableScore}
+	}
+
+	sort.SliceStable(snapshot, func(i, j int) bool {
+		if snapshot[i].healthy != snapshot[j].healthy {
+			return snapshot[i].healthy
+		}
+		return snapshot[i].score > snapshot[j].score
+	})
+
+	ordered := make([]*gatewayPeer, len(snapshot))
+	for i, s := range snapshot {
+		ordered[i] = s.peer
+	}
+	return ordered
+}
+
+// contains reports whether id belongs to one of this pool's candidate gateways. Used to
+// attribute an inbound pubsub message to a legitimate gateway even after failover has
+// reordered which candidate is currently primary.
+func (p *gatewayPool) contains(id peer.ID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, gp := range p.peers {
+		if gp.addrInfo.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *gatewayPool) stats() []GatewayStat {
+	p.mu.Lock()
+	peers := append([]*gatewayPeer(nil), p.peers...)
+	p.mu.Unlock()
+
+	stats := make([]GatewayStat, len(peers))
+	for i, gp := range peers {
+		stats[i] = gp.stat()
+	}
+	return stats
+}
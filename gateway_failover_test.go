@@ -0,0 +1,130 @@
+package p2pclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// newFailoverClient builds a Client wired to len(handlers) in-process fake gateways over
+// net.Pipe (bypassing real libp2p dialing, the same way codec_matrix_test.go's
+// newMatrixClient does), one handler per gateway, so request()'s actual candidate
+// selection and failover can be exercised end to end rather than just
+// gatewayPool.candidates() in isolation.
+func newFailoverClient(t *testing.T, handlers ...func(ProxyRequest) ProxyResponse) *Client {
+	t.Helper()
+
+	pool := newTestGatewayPool(t, len(handlers))
+	pools := make(map[peer.ID]*streamPool, len(handlers))
+
+	for i, handler := range handlers {
+		gw := pool.peers[i]
+		handler := handler
+
+		clientConn, gatewayConn := net.Pipe()
+		ps := &pooledStream{
+			s:        &fakeStream{conn: clientConn},
+			bw:       bufio.NewWriter(clientConn),
+			codec:    JSONCodec{},
+			pending:  make(map[string]chan *ProxyResponse),
+			closed:   make(chan struct{}),
+			lastUsed: time.Now(),
+		}
+		go ps.readLoop()
+
+		go func() {
+			br := bufio.NewReader(gatewayConn)
+			bw := bufio.NewWriter(gatewayConn)
+			for {
+				var req ProxyRequest
+				if err := readMessage(br, JSONCodec{}, &req); err != nil {
+					return
+				}
+				resp := handler(req)
+				resp.ID = req.ID
+				if err := writeMessage(bw, JSONCodec{}, resp); err != nil {
+					return
+				}
+				if err := bw.Flush(); err != nil {
+					return
+				}
+			}
+		}()
+
+		sp := newStreamPool(1, time.Minute, func(ctx context.Context) (*pooledStream, error) {
+			return nil, errors.New("unexpected dial: failover test pre-seeds its streams")
+		})
+		sp.streams = []*pooledStream{ps}
+		pools[gw.addrInfo.ID] = sp
+	}
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := NewEd25519KeySigner(priv)
+	if err != nil {
+		t.Fatalf("NewEd25519KeySigner: %v", err)
+	}
+
+	return &Client{
+		signer:   signer,
+		did:      signer.DID(),
+		addrInfo: pool.peers[0].addrInfo,
+		gateways: pool,
+		codec:    JSONCodec{},
+		nonces:   NewRandomNonceSource(),
+		pools:    pools,
+	}
+}
+
+// TestRequestFailsOverToNextGatewayOnApplicationError reproduces the maintainer's repro:
+// the best-scored (first-tried) gateway returns a healthy transport response carrying an
+// application-level error (e.g. an auth rejection), and a second gateway is healthy.
+// request() must try the second gateway rather than surfacing the first's rejection, and
+// the failing gateway's score must reflect the failure.
+func TestRequestFailsOverToNextGatewayOnApplicationError(t *testing.T) {
+	var failingCalls, healthyCalls int32
+
+	c := newFailoverClient(t,
+		func(req ProxyRequest) ProxyResponse {
+			atomic.AddInt32(&failingCalls, 1)
+			return ProxyResponse{Status: 401, Error: "auth rejected"}
+		},
+		func(req ProxyRequest) ProxyResponse {
+			atomic.AddInt32(&healthyCalls, 1)
+			body, _ := JSONCodec{}.Marshal([]Plan{{ID: 1, Name: "starter"}})
+			return ProxyResponse{Status: 200, Body: body}
+		},
+	)
+
+	plans, err := c.Plans(context.Background())
+	if err != nil {
+		t.Fatalf("Plans: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Name != "starter" {
+		t.Fatalf("Plans = %+v, want one plan named starter", plans)
+	}
+	if atomic.LoadInt32(&failingCalls) == 0 {
+		t.Fatalf("expected the failing gateway to have been tried")
+	}
+	if atomic.LoadInt32(&healthyCalls) == 0 {
+		t.Fatalf("expected the call to fail over to the healthy gateway")
+	}
+
+	failingGW := c.gateways.peers[0]
+	score, lastErr := failingGW.snapshot()
+	if lastErr == nil {
+		t.Fatalf("expected the failing gateway's recorded error to reflect the auth rejection")
+	}
+	if score >= 0 {
+		t.Fatalf("expected the failing gateway's score to have dropped below zero, got %.2f", score)
+	}
+}
@@ -0,0 +1,129 @@
+package p2pclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// testGatewayAddr returns a syntactically valid gateway multiaddr with a fresh peer ID, so
+// gatewayPool tests can exercise real multiaddr/peer parsing without a live libp2p host.
+func testGatewayAddr(t *testing.T, port int) string {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("peer ID from key: %v", err)
+	}
+	return fmt.Sprintf("/ip4/127.0.0.1/tcp/%d/p2p/%s", port, id.String())
+}
+
+func newTestGatewayPool(t *testing.T, n int) *gatewayPool {
+	t.Helper()
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = testGatewayAddr(t, 4000+i)
+	}
+	pool, err := newGatewayPool(addrs)
+	if err != nil {
+		t.Fatalf("newGatewayPool: %v", err)
+	}
+	t.Cleanup(pool.close)
+	return pool
+}
+
+func TestGatewayPoolCandidatesOrdersHealthyBeforeUnhealthy(t *testing.T) {
+	pool := newTestGatewayPool(t, 2)
+	params := pool.scoreParams()
+
+	healthy, flaky := pool.peers[0], pool.peers[1]
+	healthy.recordSuccess(5*time.Millisecond, params)
+	flaky.recordFailure(errors.New("timeout"), params)
+	flaky.recordFailure(errors.New("timeout"), params)
+	flaky.recordFailure(errors.New("timeout"), params)
+
+	candidates := pool.candidates()
+	if candidates[0] != healthy {
+		t.Fatalf("expected the healthy gateway to be tried first after repeated failures on the other")
+	}
+}
+
+func TestGatewayPoolPrefersLowerLatency(t *testing.T) {
+	pool := newTestGatewayPool(t, 2)
+	params := pool.scoreParams()
+
+	fast, slow := pool.peers[0], pool.peers[1]
+	for i := 0; i < 5; i++ {
+		fast.recordSuccess(2*time.Millisecond, params)
+		slow.recordSuccess(200*time.Millisecond, params)
+	}
+
+	candidates := pool.candidates()
+	if candidates[0] != fast {
+		t.Fatalf("expected the lower-latency gateway to rank first, got score fast=%.2f slow=%.2f",
+			mustScore(fast), mustScore(slow))
+	}
+}
+
+func TestGatewayPoolFallsBackToUnhealthyIfAllAreUnhealthy(t *testing.T) {
+	pool := newTestGatewayPool(t, 2)
+	params := pool.scoreParams()
+
+	for _, gw := range pool.peers {
+		gw.recordFailure(errors.New("stream open failed"), params)
+		gw.recordFailure(errors.New("stream open failed"), params)
+		gw.recordFailure(errors.New("stream open failed"), params)
+	}
+
+	candidates := pool.candidates()
+	if len(candidates) != 2 {
+		t.Fatalf("expected both unhealthy gateways to still be returned as a last resort, got %d", len(candidates))
+	}
+}
+
+func TestGatewayPeerDecayRecoversScoreOverTime(t *testing.T) {
+	pool := newTestGatewayPool(t, 1)
+	params := pool.scoreParams()
+
+	gw := pool.peers[0]
+	gw.recordFailure(errors.New("timeout"), params)
+	before, _ := gw.snapshot()
+
+	gw.decay()
+	after, _ := gw.snapshot()
+
+	if after <= before {
+		t.Fatalf("expected decay to move score back toward zero: before=%.2f after=%.2f", before, after)
+	}
+}
+
+func TestGatewayPoolContainsTracksEveryCandidate(t *testing.T) {
+	pool := newTestGatewayPool(t, 2)
+
+	for _, gw := range pool.peers {
+		if !pool.contains(gw.addrInfo.ID) {
+			t.Fatalf("expected pool to contain every one of its own candidate peer IDs")
+		}
+	}
+
+	other := testGatewayAddr(t, 4999)
+	otherPeer, err := newGatewayPeer(other)
+	if err != nil {
+		t.Fatalf("newGatewayPeer: %v", err)
+	}
+	if pool.contains(otherPeer.addrInfo.ID) {
+		t.Fatalf("expected pool not to contain an unrelated peer ID")
+	}
+}
+
+func mustScore(gw *gatewayPeer) float64 {
+	score, _ := gw.snapshot()
+	return score
+}
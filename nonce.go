@@ -0,0 +1,81 @@
+package p2pclient
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"sync"
+)
+
+// maxTrackedNonces bounds the LRU a RandomNonceSource uses to guarantee that two nonces it
+// issues never collide within this process, even though a 128-bit random value colliding
+// is already astronomically unlikely.
+const maxTrackedNonces = 4096
+
+// NonceSource generates the nonce used in a request's canonical signing payload. Swap in a
+// custom implementation with WithNonceSource if you need nonces drawn from somewhere other
+// than crypto/rand, e.g. deterministic values in tests.
+type NonceSource interface {
+	// Next returns a fresh nonce. Implementations must be safe for concurrent use.
+	Next() (string, error)
+}
+
+// RandomNonceSource is the default NonceSource: 128 bits from crypto/rand, base32-encoded,
+// with a bounded LRU of recently issued values so a caller never accidentally reuses one.
+type RandomNonceSource struct {
+	mu    sync.Mutex
+	seen  map[string]*list.Element
+	order *list.List
+}
+
+// NewRandomNonceSource builds a RandomNonceSource.
+func NewRandomNonceSource() *RandomNonceSource {
+	return &RandomNonceSource{
+		seen:  make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Next returns a fresh base32-encoded 128-bit nonce, retrying on the vanishingly unlikely
+// event that it collides with one of the recently issued nonces tracked in the LRU.
+func (s *RandomNonceSource) Next() (string, error) {
+	for {
+		var buf [16]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return "", fmt.Errorf("failed to read random nonce: %w", err)
+		}
+		nonce := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf[:])
+
+		if s.track(nonce) {
+			return nonce, nil
+		}
+	}
+}
+
+// track records nonce as issued and reports whether it was new, evicting the oldest tracked
+// nonce once the LRU is over maxTrackedNonces.
+func (s *RandomNonceSource) track(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, dup := s.seen[nonce]; dup {
+		return false
+	}
+
+	s.seen[nonce] = s.order.PushBack(nonce)
+	if s.order.Len() > maxTrackedNonces {
+		oldest := s.order.Front()
+		s.order.Remove(oldest)
+		delete(s.seen, oldest.Value.(string))
+	}
+
+	return true
+}
+
+// WithNonceSource overrides the NonceSource used by the nonce-generating public methods
+// (CreateServices, ListServices, GetService, ServiceAction, Plans). Defaults to a
+// RandomNonceSource.
+func WithNonceSource(source NonceSource) Option {
+	return func(c *Client) { c.nonces = source }
+}
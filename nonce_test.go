@@ -0,0 +1,50 @@
+package p2pclient
+
+import "testing"
+
+func TestRandomNonceSourceProducesUniqueNonces(t *testing.T) {
+	src := NewRandomNonceSource()
+
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		nonce, err := src.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if nonce == "" {
+			t.Fatalf("expected a non-empty nonce")
+		}
+		if seen[nonce] {
+			t.Fatalf("nonce %q was issued twice", nonce)
+		}
+		seen[nonce] = true
+	}
+}
+
+func TestRandomNonceSourceTrackRejectsDuplicates(t *testing.T) {
+	src := NewRandomNonceSource()
+
+	if !src.track("n-1") {
+		t.Fatalf("expected first sighting of a nonce to be accepted")
+	}
+	if src.track("n-1") {
+		t.Fatalf("expected a repeated nonce to be rejected")
+	}
+}
+
+func TestRandomNonceSourceTrackEvictsOldestPastBound(t *testing.T) {
+	src := NewRandomNonceSource()
+
+	for i := 0; i < maxTrackedNonces; i++ {
+		if !src.track(string(rune(i))) {
+			t.Fatalf("expected nonce %d to be accepted", i)
+		}
+	}
+	// pushes the LRU over its bound, evicting the very first nonce tracked.
+	if !src.track("overflow") {
+		t.Fatalf("expected the new nonce to be accepted")
+	}
+	if !src.track(string(rune(0))) {
+		t.Fatalf("expected the evicted nonce to be re-acceptable")
+	}
+}
@@ -2,20 +2,25 @@ package p2pclient
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 )
 
-// Plans returns a list of available plans.
+// Plans returns a list of available plans. Plans is a public, unauthenticated endpoint,
+// but a replay-safe nonce and the clock-corrected timestamp are still generated for the
+// canonical signature, same as every other request.
 func (c *Client) Plans(ctx context.Context) ([]Plan, error) {
-	// public api, no need nonce and ts
-	resp, err := c.request(ctx, PlansEndpoint.Method, PlansEndpoint.Uri, nil, "n1", 0)
+	nonce, err := c.nonces.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	resp, err := c.request(ctx, PlansEndpoint.Method, PlansEndpoint.Uri, nil, nonce, c.now())
 	if err != nil {
 		return nil, err
 	}
 
 	var plans []Plan
-	err = json.Unmarshal(resp.Body, &plans)
+	err = c.codec.Unmarshal(resp.Body, &plans)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal Plans: %w", err)
 	}
@@ -0,0 +1,45 @@
+package p2pclient
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var authNonceRE = regexp.MustCompile(`nonce=([^;]+)`)
+
+func TestPlansGeneratesAFreshReplaySafeNonce(t *testing.T) {
+	var nonces []string
+	handler := func(req ProxyRequest) ProxyResponse {
+		m := authNonceRE.FindStringSubmatch(req.Headers["Authorization"])
+		if m == nil {
+			t.Fatalf("Authorization header missing nonce: %q", req.Headers["Authorization"])
+		}
+		nonces = append(nonces, m[1])
+
+		body, _ := JSONCodec{}.Marshal([]Plan{})
+		return ProxyResponse{Status: 200, Body: body}
+	}
+
+	c := newMatrixClient(t, JSONCodec{}, handler)
+
+	if _, err := c.Plans(context.Background()); err != nil {
+		t.Fatalf("Plans: %v", err)
+	}
+	if _, err := c.Plans(context.Background()); err != nil {
+		t.Fatalf("Plans: %v", err)
+	}
+
+	if len(nonces) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(nonces))
+	}
+	if nonces[0] == nonces[1] {
+		t.Fatalf("expected each Plans call to sign with a distinct nonce, got %q twice", nonces[0])
+	}
+	for _, n := range nonces {
+		if strings.EqualFold(n, "n1") {
+			t.Fatalf("expected Plans to no longer sign with the old hardcoded nonce %q", n)
+		}
+	}
+}
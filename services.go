@@ -2,10 +2,8 @@ package p2pclient
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
 )
 
 // ServiceAction represents the management action on a service
@@ -34,8 +32,20 @@ type CreateServiceRequest struct {
 }
 
 // CreateServices creates a new service given an optional custom name
-// plan id, os type, number of instances and a public key.
-func (c *Client) CreateServices(ctx context.Context, request CreateServiceRequest, nonce string) ([]Service, error) {
+// plan id, os type, number of instances and a public key. A replay-safe nonce is
+// generated internally; use CreateServicesWithNonce if you need a deterministic one.
+func (c *Client) CreateServices(ctx context.Context, request CreateServiceRequest) ([]Service, error) {
+	nonce, err := c.nonces.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.CreateServicesWithNonce(ctx, request, nonce)
+}
+
+// CreateServicesWithNonce is CreateServices with an explicit nonce.
+//
+// Deprecated: use CreateServices, which generates a replay-safe nonce internally.
+func (c *Client) CreateServicesWithNonce(ctx context.Context, request CreateServiceRequest, nonce string) ([]Service, error) {
 	if request.PlanID == 0 {
 		return nil, errors.New("plan id is required")
 	}
@@ -60,18 +70,18 @@ func (c *Client) CreateServices(ctx context.Context, request CreateServiceReques
 		Name:         request.Name,
 	}
 
-	bts, err := json.Marshal(req)
+	bts, err := c.codec.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal create service request: %w", err)
 	}
 
-	resp, err := c.request(ctx, CreateServicesEndpoint.Method, CreateServicesEndpoint.Uri, bts, nonce, time.Now().Unix())
+	resp, err := c.request(ctx, CreateServicesEndpoint.Method, CreateServicesEndpoint.Uri, bts, nonce, c.now())
 	if err != nil {
 		return nil, err
 	}
 
 	var services []Service
-	err = json.Unmarshal(resp.Body, &services)
+	err = c.codec.Unmarshal(resp.Body, &services)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal CreateService: %w", err)
 	}
@@ -79,15 +89,27 @@ func (c *Client) CreateServices(ctx context.Context, request CreateServiceReques
 	return services, nil
 }
 
-// ListServices returns a list of available services.
-func (c *Client) ListServices(ctx context.Context, nonce string) ([]Service, error) {
-	resp, err := c.request(ctx, ListUserServicesEndpoint.Method, ListUserServicesEndpoint.Uri, nil, nonce, time.Now().Unix())
+// ListServices returns a list of available services. A replay-safe nonce is generated
+// internally; use ListServicesWithNonce if you need a deterministic one.
+func (c *Client) ListServices(ctx context.Context) ([]Service, error) {
+	nonce, err := c.nonces.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.ListServicesWithNonce(ctx, nonce)
+}
+
+// ListServicesWithNonce is ListServices with an explicit nonce.
+//
+// Deprecated: use ListServices, which generates a replay-safe nonce internally.
+func (c *Client) ListServicesWithNonce(ctx context.Context, nonce string) ([]Service, error) {
+	resp, err := c.request(ctx, ListUserServicesEndpoint.Method, ListUserServicesEndpoint.Uri, nil, nonce, c.now())
 	if err != nil {
 		return nil, err
 	}
 
 	var services []Service
-	err = json.Unmarshal(resp.Body, &services)
+	err = c.codec.Unmarshal(resp.Body, &services)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal ListServices: %w", err)
 	}
@@ -95,15 +117,27 @@ func (c *Client) ListServices(ctx context.Context, nonce string) ([]Service, err
 	return services, nil
 }
 
-// Get a specific service.
-func (c *Client) GetService(ctx context.Context, id uint, nonce string) (*Service, error) {
-	resp, err := c.request(ctx, GetUserServiceEndpoint.Method, fmt.Sprintf(GetUserServiceEndpoint.Uri, id), nil, nonce, time.Now().Unix())
+// GetService gets a specific service. A replay-safe nonce is generated internally; use
+// GetServiceWithNonce if you need a deterministic one.
+func (c *Client) GetService(ctx context.Context, id uint) (*Service, error) {
+	nonce, err := c.nonces.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.GetServiceWithNonce(ctx, id, nonce)
+}
+
+// GetServiceWithNonce is GetService with an explicit nonce.
+//
+// Deprecated: use GetService, which generates a replay-safe nonce internally.
+func (c *Client) GetServiceWithNonce(ctx context.Context, id uint, nonce string) (*Service, error) {
+	resp, err := c.request(ctx, GetUserServiceEndpoint.Method, fmt.Sprintf(GetUserServiceEndpoint.Uri, id), nil, nonce, c.now())
 	if err != nil {
 		return nil, err
 	}
 
 	var service Service
-	err = json.Unmarshal(resp.Body, &service)
+	err = c.codec.Unmarshal(resp.Body, &service)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal GetService: %w", err)
 	}
@@ -120,24 +154,37 @@ type serviceActionResponse struct {
 	ActionID string `json:"action_id"`
 }
 
-// ServiceAction performs an action such as start, shutdown, restart, reinstall and delete on a service.
-func (c *Client) ServiceAction(ctx context.Context, action ServiceAction, id uint, nonce string) error {
+// ServiceAction performs an action such as start, shutdown, restart, reinstall and delete
+// on a service. A replay-safe nonce is generated internally; use ServiceActionWithNonce if
+// you need a deterministic one.
+func (c *Client) ServiceAction(ctx context.Context, action ServiceAction, id uint) error {
+	nonce, err := c.nonces.Next()
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.ServiceActionWithNonce(ctx, action, id, nonce)
+}
+
+// ServiceActionWithNonce is ServiceAction with an explicit nonce.
+//
+// Deprecated: use ServiceAction, which generates a replay-safe nonce internally.
+func (c *Client) ServiceActionWithNonce(ctx context.Context, action ServiceAction, id uint, nonce string) error {
 	if action == "" {
 		return errors.New("action is required")
 	}
-	reqBody, err := json.Marshal(serviceActionRequest{
+	reqBody, err := c.codec.Marshal(serviceActionRequest{
 		Action: string(action),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal service action request: %w", err)
 	}
-	resp, err := c.request(ctx, ManageServiceEndpoint.Method, fmt.Sprintf(ManageServiceEndpoint.Uri, id), reqBody, nonce, time.Now().Unix())
+	resp, err := c.request(ctx, ManageServiceEndpoint.Method, fmt.Sprintf(ManageServiceEndpoint.Uri, id), reqBody, nonce, c.now())
 	if err != nil {
 		return err
 	}
 
 	var actionResp serviceActionResponse
-	err = json.Unmarshal(resp.Body, &actionResp)
+	err = c.codec.Unmarshal(resp.Body, &actionResp)
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal ServiceAction: %w", err)
 	}
@@ -0,0 +1,55 @@
+package p2pclient
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Signer abstracts the DID method and key material used to authenticate
+// requests to the p2p-gateway. This lets callers plug in alternative DID
+// methods (did:web, secp256k1 did:key variants, ...) or key custody models
+// (HSM/KMS-backed signing) without touching the request plumbing.
+type Signer interface {
+	// DID returns the signer's DID.
+	DID() string
+	// Sign returns the signature over payload.
+	Sign(payload []byte) ([]byte, error)
+	// AuthHeader formats the Authorization header value for a signed request.
+	AuthHeader(sig []byte, ts int64, nonce string) string
+}
+
+// Ed25519KeySigner is the default Signer, backed by a raw Ed25519 private key
+// and a did:key DID derived from its public key. This matches the client's
+// original behavior.
+type Ed25519KeySigner struct {
+	privKey ed25519.PrivateKey
+	did     string
+}
+
+// NewEd25519KeySigner builds an Ed25519KeySigner from a private key. The DID
+// is derived from the key's public half via Ed25519PubKeyToDID.
+func NewEd25519KeySigner(privKey ed25519.PrivateKey) (*Ed25519KeySigner, error) {
+	pubKey := privKey.Public().(ed25519.PublicKey)
+	did, err := Ed25519PubKeyToDID(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DID from public key: %w", err)
+	}
+
+	return &Ed25519KeySigner{privKey: privKey, did: did}, nil
+}
+
+// DID returns the did:key DID derived from the signer's public key.
+func (s *Ed25519KeySigner) DID() string {
+	return s.did
+}
+
+// Sign signs payload with the underlying Ed25519 private key.
+func (s *Ed25519KeySigner) Sign(payload []byte) ([]byte, error) {
+	return ed25519.Sign(s.privKey, payload), nil
+}
+
+// AuthHeader formats the "DID ...;sig=...;ts=...;nonce=..." header used by
+// today's did:key gateway.
+func (s *Ed25519KeySigner) AuthHeader(sig []byte, ts int64, nonce string) string {
+	return formatDIDAuthHeader(s.did, sig, ts, nonce)
+}
@@ -0,0 +1,245 @@
+package p2pclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// defaultMaxStreams is the default bound on concurrently open streams per pool.
+const defaultMaxStreams = 4
+
+// defaultIdleTimeout is how long an unused pooled stream is kept open before it's closed.
+const defaultIdleTimeout = 30 * time.Second
+
+// Option configures a Client at construction time; pass to New or NewWithSigner.
+type Option func(*Client)
+
+// WithMaxStreams bounds how many streams a Client keeps open to the gateway at once.
+func WithMaxStreams(n int) Option {
+	return func(c *Client) { c.maxStreams = n }
+}
+
+// WithIdleTimeout sets how long an unused pooled stream is kept open before being closed.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Client) { c.idleTimeout = d }
+}
+
+// WithoutPooling disables stream pooling: request() goes back to opening and closing a
+// fresh stream for every call, same as before stream pooling was introduced. Useful for
+// debugging whether an issue is pooling-related.
+func WithoutPooling() Option {
+	return func(c *Client) { c.poolingDisabled = true }
+}
+
+// pooledStream is a single open libp2p stream multiplexing requests by ID.
+type pooledStream struct {
+	s     network.Stream
+	bw    *bufio.Writer
+	codec Codec
+
+	writeMu sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   map[string]chan *ProxyResponse
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu       sync.Mutex
+	lastUsed time.Time
+}
+
+// openPooledStreamTo opens a new pooled stream to gw.
+func (c *Client) openPooledStreamTo(ctx context.Context, gw *gatewayPeer) (*pooledStream, error) {
+	if err := c.host.Connect(ctx, *gw.addrInfo); err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	s, err := c.host.NewStream(ctx, gw.addrInfo.ID, protocol.ID(protocolIDFor(c.codec)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	ps := &pooledStream{
+		s:        s,
+		bw:       bufio.NewWriter(s),
+		codec:    c.codec,
+		pending:  make(map[string]chan *ProxyResponse),
+		closed:   make(chan struct{}),
+		lastUsed: time.Now(),
+	}
+	go ps.readLoop()
+
+	return ps, nil
+}
+
+// readLoop demultiplexes responses read off the stream to the channel registered for
+// their request ID. It runs until the stream errors, at which point every still-pending
+// caller is woken with the error so nothing blocks forever on a dead stream.
+func (ps *pooledStream) readLoop() {
+	br := bufio.NewReader(ps.s)
+	for {
+		var resp ProxyResponse
+		if err := readMessage(br, ps.codec, &resp); err != nil {
+			ps.fail(fmt.Errorf("stream closed: %w", err))
+			return
+		}
+
+		ps.pendingMu.Lock()
+		ch, ok := ps.pending[resp.ID]
+		if ok {
+			delete(ps.pending, resp.ID)
+		}
+		ps.pendingMu.Unlock()
+
+		if ok {
+			ch <- &resp
+		}
+	}
+}
+
+func (ps *pooledStream) fail(err error) {
+	ps.closeOnce.Do(func() {
+		close(ps.closed)
+		if ps.s != nil {
+			ps.s.Close()
+		}
+
+		ps.pendingMu.Lock()
+		defer ps.pendingMu.Unlock()
+		for id, ch := range ps.pending {
+			ch <- &ProxyResponse{ID: id, Error: err.Error()}
+		}
+		ps.pending = nil
+	})
+}
+
+// send writes req and returns a channel that receives its matching response.
+func (ps *pooledStream) send(req ProxyRequest) (chan *ProxyResponse, error) {
+	select {
+	case <-ps.closed:
+		return nil, fmt.Errorf("stream is closed")
+	default:
+	}
+
+	ch := make(chan *ProxyResponse, 1)
+
+	ps.pendingMu.Lock()
+	ps.pending[req.ID] = ch
+	ps.pendingMu.Unlock()
+
+	ps.writeMu.Lock()
+	defer ps.writeMu.Unlock()
+	if err := writeMessage(ps.bw, ps.codec, req); err != nil {
+		ps.pendingMu.Lock()
+		delete(ps.pending, req.ID)
+		ps.pendingMu.Unlock()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if err := ps.bw.Flush(); err != nil {
+		ps.pendingMu.Lock()
+		delete(ps.pending, req.ID)
+		ps.pendingMu.Unlock()
+		return nil, fmt.Errorf("failed to flush: %w", err)
+	}
+
+	ps.mu.Lock()
+	ps.lastUsed = time.Now()
+	ps.mu.Unlock()
+
+	return ch, nil
+}
+
+func (ps *pooledStream) isClosed() bool {
+	select {
+	case <-ps.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ps *pooledStream) idleSince() time.Time {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.lastUsed
+}
+
+// streamPool holds a bounded set of open streams to a single gateway peer and hands them
+// out round-robin, opening new ones lazily up to maxStreams. openFn is injected so the
+// pool can be exercised in tests without a real libp2p host.
+type streamPool struct {
+	mu          sync.Mutex
+	streams     []*pooledStream
+	next        int
+	maxStreams  int
+	idleTimeout time.Duration
+	openFn      func(ctx context.Context) (*pooledStream, error)
+}
+
+func newStreamPool(maxStreams int, idleTimeout time.Duration, openFn func(ctx context.Context) (*pooledStream, error)) *streamPool {
+	return &streamPool{maxStreams: maxStreams, idleTimeout: idleTimeout, openFn: openFn}
+}
+
+// acquire returns a stream to send a request on, opening a fresh one if the pool has
+// spare capacity and otherwise reusing an existing one round-robin.
+func (p *streamPool) acquire(ctx context.Context) (*pooledStream, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.evictLocked()
+
+	if len(p.streams) < p.maxStreams || len(p.streams) == 0 {
+		ps, err := p.openFn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.streams = append(p.streams, ps)
+		return ps, nil
+	}
+
+	ps := p.streams[p.next%len(p.streams)]
+	p.next++
+	return ps, nil
+}
+
+// evictLocked drops streams that have failed or gone idle past idleTimeout. Callers must
+// hold p.mu.
+func (p *streamPool) evictLocked() {
+	live := p.streams[:0]
+	for _, ps := range p.streams {
+		if ps.isClosed() {
+			continue
+		}
+		if p.idleTimeout > 0 && time.Since(ps.idleSince()) > p.idleTimeout {
+			ps.fail(fmt.Errorf("idle timeout exceeded"))
+			continue
+		}
+		live = append(live, ps)
+	}
+	p.streams = live
+}
+
+// close fails every stream currently held by the pool, which stops their readLoop
+// goroutines. Safe to call once the pool is no longer in use.
+func (p *streamPool) close() {
+	p.mu.Lock()
+	streams := p.streams
+	p.streams = nil
+	p.mu.Unlock()
+
+	for _, ps := range streams {
+		ps.fail(fmt.Errorf("stream pool closed"))
+	}
+}
+
+func (c *Client) nextRequestID() string {
+	return fmt.Sprintf("%d", atomic.AddUint64(&c.reqSeq, 1))
+}
@@ -0,0 +1,188 @@
+package p2pclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// fakeStream is a minimal network.Stream backed by an in-memory net.Pipe, just enough to
+// exercise pooledStream's framing and demux logic without a real libp2p host.
+type fakeStream struct {
+	network.Stream
+	conn net.Conn
+}
+
+func (f *fakeStream) Read(p []byte) (int, error)  { return f.conn.Read(p) }
+func (f *fakeStream) Write(p []byte) (int, error) { return f.conn.Write(p) }
+func (f *fakeStream) Close() error                { return f.conn.Close() }
+
+// newFakePooledStream wires a pooledStream to one end of a net.Pipe and runs a fake
+// gateway on the other end, echoing back a ProxyResponse with the same ID for every
+// ProxyRequest it reads, after an optional artificial delay.
+func newFakePooledStream(delay time.Duration) *pooledStream {
+	clientConn, gatewayConn := net.Pipe()
+
+	ps := &pooledStream{
+		s:       &fakeStream{conn: clientConn},
+		bw:      bufio.NewWriter(clientConn),
+		codec:   JSONCodec{},
+		pending: make(map[string]chan *ProxyResponse),
+		closed:  make(chan struct{}),
+	}
+	go ps.readLoop()
+
+	go func() {
+		br := bufio.NewReader(gatewayConn)
+		bw := bufio.NewWriter(gatewayConn)
+		for {
+			var req ProxyRequest
+			if err := readMessage(br, JSONCodec{}, &req); err != nil {
+				return
+			}
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			resp := ProxyResponse{ID: req.ID, Status: 200, Body: []byte(fmt.Sprintf("echo:%s", req.Path))}
+			if err := writeMessage(bw, JSONCodec{}, resp); err != nil {
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return ps
+}
+
+func TestPooledStreamSendDemuxesConcurrentRequests(t *testing.T) {
+	ps := newFakePooledStream(0)
+
+	const n = 200
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := ProxyRequest{ID: fmt.Sprintf("req-%d", i), Method: "GET", Path: fmt.Sprintf("/v1/services/%d", i)}
+			ch, err := ps.send(req)
+			if err != nil {
+				t.Errorf("send: %v", err)
+				return
+			}
+			resp := <-ch
+			want := fmt.Sprintf("echo:%s", req.Path)
+			if string(resp.Body) != want {
+				t.Errorf("request %d: got response for wrong request: %q != %q", i, resp.Body, want)
+			}
+			if resp.ID != req.ID {
+				t.Errorf("request %d: response ID %q != request ID %q", i, resp.ID, req.ID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestStreamPoolReusesUpToMaxStreams(t *testing.T) {
+	var opened int
+	openFn := func(ctx context.Context) (*pooledStream, error) {
+		opened++
+		return &pooledStream{pending: make(map[string]chan *ProxyResponse), closed: make(chan struct{}), lastUsed: time.Now()}, nil
+	}
+
+	pool := newStreamPool(2, time.Minute, openFn)
+	ctx := context.Background()
+
+	seen := map[*pooledStream]bool{}
+	for i := 0; i < 6; i++ {
+		ps, err := pool.acquire(ctx)
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		seen[ps] = true
+	}
+
+	if opened != 2 {
+		t.Fatalf("expected exactly 2 streams to be opened for maxStreams=2, got %d", opened)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected requests to be spread across 2 distinct streams, got %d", len(seen))
+	}
+}
+
+func TestStreamPoolEvictsClosedStreams(t *testing.T) {
+	dead := &pooledStream{pending: make(map[string]chan *ProxyResponse), closed: make(chan struct{})}
+	close(dead.closed)
+
+	var opened int
+	openFn := func(ctx context.Context) (*pooledStream, error) {
+		opened++
+		return &pooledStream{pending: make(map[string]chan *ProxyResponse), closed: make(chan struct{}), lastUsed: time.Now()}, nil
+	}
+
+	pool := newStreamPool(1, time.Minute, openFn)
+	pool.streams = []*pooledStream{dead}
+
+	ps, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if ps == dead {
+		t.Fatalf("expected acquire to replace a closed stream with a fresh one")
+	}
+	if opened != 1 {
+		t.Fatalf("expected exactly one fresh stream to be opened, got %d", opened)
+	}
+}
+
+func TestStreamPoolCloseFailsAllHeldStreams(t *testing.T) {
+	pool := newStreamPool(2, time.Minute, func(ctx context.Context) (*pooledStream, error) {
+		return &pooledStream{pending: make(map[string]chan *ProxyResponse), closed: make(chan struct{}), lastUsed: time.Now()}, nil
+	})
+
+	first, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	pool.close()
+
+	if !first.isClosed() {
+		t.Fatalf("expected close to fail every stream the pool was holding")
+	}
+}
+
+func BenchmarkPooledStreamSend(b *testing.B) {
+	ps := newFakePooledStream(0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := ProxyRequest{ID: fmt.Sprintf("bench-%d", i), Method: "GET", Path: "/v1/services"}
+		ch, err := ps.send(req)
+		if err != nil {
+			b.Fatalf("send: %v", err)
+		}
+		<-ch
+	}
+}
+
+func BenchmarkOneShotStreamPerRequest(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ps := newFakePooledStream(0)
+		req := ProxyRequest{ID: fmt.Sprintf("bench-%d", i), Method: "GET", Path: "/v1/services"}
+		ch, err := ps.send(req)
+		if err != nil {
+			b.Fatalf("send: %v", err)
+		}
+		<-ch
+		ps.fail(io.EOF) // close the stream, simulating the one-shot open/close-per-call cost
+	}
+}